@@ -0,0 +1,30 @@
+package iso
+
+import (
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// diskArtifact wraps a packer.Artifact (the OVF/OVA bundle produced by
+// vboxcommon.NewArtifact) and folds in any extra files -- such as a
+// converted disk_output_format image, its checksum sidecar, or an exported
+// NVRAM file -- that were produced outside of the export directory listing.
+type diskArtifact struct {
+	packer.Artifact
+	extraFiles []string
+}
+
+func (a *diskArtifact) Files() []string {
+	return append(a.Artifact.Files(), a.extraFiles...)
+}
+
+// appendArtifactFile records an extra file, produced by a step that ran
+// after export, to be folded into the final artifact's file list.
+func appendArtifactFile(state multistep.StateBag, path string) {
+	var files []string
+	if raw, ok := state.GetOk("extra_artifact_files"); ok {
+		files = raw.([]string)
+	}
+
+	state.Put("extra_artifact_files", append(files, path))
+}
@@ -88,6 +88,8 @@ type Config struct {
 	// The type of controller that the primary hard drive is attached to,
 	// defaults to ide. When set to sata, the drive is attached to an AHCI SATA
 	// controller. When set to scsi, the drive is attached to an LsiLogic SCSI
+	// controller. When set to virtio-scsi, the drive is attached to a VirtIO
+	// SCSI controller. When set to nvme, the drive is attached to an NVMe
 	// controller.
 	HardDriveInterface string `mapstructure:"hard_drive_interface" required:"false"`
 	// The number of ports available on any SATA controller created, defaults
@@ -95,12 +97,19 @@ type Config struct {
 	// controller. Increasing this value can be useful if you want to attach
 	// additional drives.
 	SATAPortCount int `mapstructure:"sata_port_count" required:"false"`
+	// The number of ports, i.e. NVMe namespaces, available on any NVMe
+	// controller created, defaults to 1. VirtualBox supports up to 255 ports
+	// on a maximum of 1 NVMe controller.
+	NVMePortCount int `mapstructure:"nvme_port_count" required:"false"`
 	// Forces some guests (i.e. Windows 7+) to treat disks as SSDs and stops
 	// them from performing disk fragmentation. Also set hard_drive_discard to
 	// true to enable TRIM support.
 	HardDriveNonrotational bool `mapstructure:"hard_drive_nonrotational" required:"false"`
 	// The type of controller that the ISO is attached to, defaults to ide.
 	// When set to sata, the drive is attached to an AHCI SATA controller.
+	// When set to virtio-scsi, the drive is attached to a VirtIO SCSI
+	// controller. When set to nvme, the drive is attached to an NVMe
+	// controller.
 	ISOInterface string `mapstructure:"iso_interface" required:"false"`
 	// Set this to true if you would like to keep the VM registered with
 	// virtualbox. Defaults to false.
@@ -109,6 +118,33 @@ type Config struct {
 	// if the build output is not the resultant image, but created inside the
 	// VM.
 	SkipExport bool `mapstructure:"skip_export" required:"false"`
+	// The firmware to use for the VM, either bios, efi, or efi64. Defaults to
+	// bios. Set this to efi or efi64 to build Secure Boot or UEFI-only images
+	// such as Windows 11.
+	Firmware string `mapstructure:"firmware" required:"false"`
+	// The path to an NVRAM template to seed the VM with, such as one
+	// containing pre-populated Secure Boot PK/KEK/db entries. Only used when
+	// firmware is efi or efi64. By default VirtualBox's own NVRAM defaults
+	// are used.
+	NVRAMFile string `mapstructure:"nvram_file" required:"false"`
+	// The format of an additional disk artifact to produce alongside (or
+	// instead of, if skip_export is true) the OVF/OVA bundle, which remains
+	// controlled by format. Valid options are vmdk, qcow2, or raw; leave
+	// unset to produce no additional disk artifact. vmdk and raw are
+	// produced with VBoxManage clonemedium; qcow2 additionally requires
+	// qemu-img to be available on the PATH.
+	DiskOutputFormat string `mapstructure:"disk_output_format" required:"false"`
+	// If set, Packer takes a snapshot with this name once the communicator
+	// comes up, before any provisioners run. On a later build against a VM
+	// of the same vm_name, if a snapshot with this name already exists,
+	// Packer restores it and skips straight to connecting and provisioning
+	// instead of reinstalling the OS from scratch. When set, a successful
+	// build leaves the VM registered, whatever keep_registered says.
+	SnapshotName string `mapstructure:"snapshot_name" required:"false"`
+	// When true, the snapshot named by snapshot_name is deleted at the end
+	// of a successful build. Defaults to false, which keeps the snapshot
+	// around so the next build can restore it.
+	DiscardSnapshot bool `mapstructure:"discard_snapshot" required:"false"`
 	// This is the name of the OVF file for the new virtual machine, without
 	// the file extension. By default this is packer-BUILDNAME, where
 	// "BUILDNAME" is the name of the build.
@@ -192,9 +228,11 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, error) {
 			"packer-%s-%d", b.config.PackerBuildName, interpolate.InitTime.Unix())
 	}
 
-	if b.config.HardDriveInterface != "ide" && b.config.HardDriveInterface != "sata" && b.config.HardDriveInterface != "scsi" {
+	if b.config.HardDriveInterface != "ide" && b.config.HardDriveInterface != "sata" &&
+		b.config.HardDriveInterface != "scsi" && b.config.HardDriveInterface != "virtio-scsi" &&
+		b.config.HardDriveInterface != "nvme" {
 		errs = packer.MultiErrorAppend(
-			errs, errors.New("hard_drive_interface can only be ide, sata, or scsi"))
+			errs, errors.New("hard_drive_interface can only be ide, sata, scsi, virtio-scsi, or nvme"))
 	}
 
 	if b.config.SATAPortCount == 0 {
@@ -206,9 +244,19 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, error) {
 			errs, errors.New("sata_port_count cannot be greater than 30"))
 	}
 
-	if b.config.ISOInterface != "ide" && b.config.ISOInterface != "sata" {
+	if b.config.NVMePortCount == 0 {
+		b.config.NVMePortCount = 1
+	}
+
+	if b.config.NVMePortCount > 255 {
+		errs = packer.MultiErrorAppend(
+			errs, errors.New("nvme_port_count cannot be greater than 255"))
+	}
+
+	if b.config.ISOInterface != "ide" && b.config.ISOInterface != "sata" &&
+		b.config.ISOInterface != "virtio-scsi" && b.config.ISOInterface != "nvme" {
 		errs = packer.MultiErrorAppend(
-			errs, errors.New("iso_interface can only be ide or sata"))
+			errs, errors.New("iso_interface can only be ide, sata, virtio-scsi, or nvme"))
 	}
 
 	validMode := false
@@ -234,6 +282,40 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, error) {
 		b.config.GuestAdditionsSHA256 = strings.ToLower(b.config.GuestAdditionsSHA256)
 	}
 
+	if b.config.Firmware == "" {
+		b.config.Firmware = "bios"
+	}
+
+	if b.config.Firmware != "bios" && b.config.Firmware != "efi" && b.config.Firmware != "efi64" {
+		errs = packer.MultiErrorAppend(
+			errs, errors.New("firmware can only be bios, efi, or efi64"))
+	}
+
+	if b.config.NVRAMFile != "" && b.config.Firmware == "bios" {
+		errs = packer.MultiErrorAppend(
+			errs, errors.New("nvram_file requires firmware to be efi or efi64"))
+	}
+
+	if b.config.DiskOutputFormat != "" {
+		validDiskOutputFormats := []string{"vmdk", "qcow2", "raw"}
+		validDiskOutputFormat := false
+		for _, format := range validDiskOutputFormats {
+			if b.config.DiskOutputFormat == format {
+				validDiskOutputFormat = true
+				break
+			}
+		}
+		if !validDiskOutputFormat {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("disk_output_format is invalid. Must be one of: %v", validDiskOutputFormats))
+		}
+	}
+
+	if b.config.DiscardSnapshot && b.config.SnapshotName == "" {
+		errs = packer.MultiErrorAppend(
+			errs, errors.New("discard_snapshot requires snapshot_name to be set"))
+	}
+
 	// Warnings
 	if b.config.ShutdownCommand == "" {
 		warnings = append(warnings,
@@ -291,7 +373,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Comm:         &b.config.Comm,
 		},
 		new(vboxcommon.StepSuppressMessages),
+		new(stepRestoreSnapshot),
 		new(stepCreateVM),
+		new(stepConfigureNVRAM),
 		new(stepCreateDisk),
 		new(stepAttachISO),
 		&vboxcommon.StepAttachGuestAdditions{
@@ -317,13 +401,15 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		&vboxcommon.StepRun{
 			Headless: b.config.Headless,
 		},
-		&vboxcommon.StepTypeBootCommand{
-			BootWait:      b.config.BootWait,
-			BootCommand:   b.config.FlatBootCommand(),
-			VMName:        b.config.VMName,
-			Ctx:           b.config.ctx,
-			GroupInterval: b.config.BootConfig.BootGroupInterval,
-			Comm:          &b.config.Comm,
+		&stepSkippableIfRestored{
+			Step: &vboxcommon.StepTypeBootCommand{
+				BootWait:      b.config.BootWait,
+				BootCommand:   b.config.FlatBootCommand(),
+				VMName:        b.config.VMName,
+				Ctx:           b.config.ctx,
+				GroupInterval: b.config.BootConfig.BootGroupInterval,
+				Comm:          &b.config.Comm,
+			},
 		},
 		&communicator.StepConnect{
 			Config:    &b.config.SSHConfig.Comm,
@@ -332,6 +418,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			SSHPort:   vboxcommon.SSHPort,
 			WinRMPort: vboxcommon.SSHPort,
 		},
+		new(stepTakeSnapshot),
 		&vboxcommon.StepUploadVersion{
 			Path: *b.config.VBoxVersionFile,
 		},
@@ -349,6 +436,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Timeout: b.config.ShutdownTimeout,
 			Delay:   b.config.PostShutdownDelay,
 		},
+		new(stepFinishSnapshot),
 		&vboxcommon.StepRemoveDevices{
 			Bundling:                b.config.VBoxBundleConfig,
 			GuestAdditionsInterface: b.config.GuestAdditionsInterface,
@@ -368,6 +456,8 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 				SkipNatMapping: b.config.SSHSkipNatMapping,
 				SkipExport:     b.config.SkipExport,
 			},
+			new(stepExportNVRAM),
+			new(stepConvertDisk),
 		)
 	}
 
@@ -397,5 +487,17 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		return nil, errors.New("Build was halted.")
 	}
 
-	return vboxcommon.NewArtifact(b.config.OutputDir)
+	artifact, err := vboxcommon.NewArtifact(b.config.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if extraFiles, ok := state.GetOk("extra_artifact_files"); ok {
+		artifact = &diskArtifact{
+			Artifact:   artifact,
+			extraFiles: extraFiles.([]string),
+		}
+	}
+
+	return artifact, nil
 }
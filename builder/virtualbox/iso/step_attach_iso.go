@@ -0,0 +1,84 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step attaches the ISO to the virtual machine.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   iso_path string
+//   ui     packer.Ui
+//
+// Produces:
+//   <nothing>; whether the ISO was attached is kept on the step itself so
+//   that Cleanup knows whether to eject it.
+type stepAttachISO struct {
+	attachedIso bool
+}
+
+func (s *stepAttachISO) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if skipInstall(state) {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	isoPath := state.Get("iso_path").(string)
+
+	controllerName, _ := storageControllerInfo(config.ISOInterface)
+
+	command := []string{
+		"storageattach", config.VMName,
+		"--storagectl", controllerName,
+		"--port", "1",
+		"--device", "0",
+		"--type", "dvddrive",
+		"--medium", isoPath,
+	}
+
+	ui.Say("Attaching ISO to the new CD/DVD drive...")
+	if err := driver.VBoxManage(command...); err != nil {
+		err := fmt.Errorf("Error attaching ISO: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.attachedIso = true
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAttachISO) Cleanup(state multistep.StateBag) {
+	if !s.attachedIso {
+		return
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	controllerName, _ := storageControllerInfo(config.ISOInterface)
+
+	command := []string{
+		"storageattach", config.VMName,
+		"--storagectl", controllerName,
+		"--port", "1",
+		"--device", "0",
+		"--type", "dvddrive",
+		"--medium", "emptydrive",
+	}
+
+	if err := driver.VBoxManage(command...); err != nil {
+		ui.Error(fmt.Sprintf("Error detaching ISO: %s", err))
+	}
+}
@@ -0,0 +1,102 @@
+package iso
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step copies a user-supplied NVRAM template into the VM directory so
+// that pre-seeded Secure Boot keys and PK/KEK/db entries survive into the
+// first boot.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   <nothing>
+type stepConfigureNVRAM struct{}
+
+func (s *stepConfigureNVRAM) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+
+	if config.NVRAMFile == "" || skipInstall(state) {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	ui.Say("Copying provided NVRAM template into the VM...")
+
+	nvramPath, err := vmNVRAMPath(driver, config.VMName)
+	if err != nil {
+		err := fmt.Errorf("Error locating VM NVRAM file: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if err := copyFile(config.NVRAMFile, nvramPath); err != nil {
+		err := fmt.Errorf("Error copying NVRAM file: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepConfigureNVRAM) Cleanup(state multistep.StateBag) {}
+
+// vmNVRAMPath asks VirtualBox, via showvminfo --machinereadable, for the
+// path of the VM's NVRAM file.
+func vmNVRAMPath(driver vboxInfoDriver, vmName string) (string, error) {
+	stdout, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "BIOSNVRAMFile=") {
+			continue
+		}
+
+		path := strings.TrimPrefix(line, "BIOSNVRAMFile=")
+		return strings.Trim(path, `"`), nil
+	}
+
+	return "", fmt.Errorf("BIOSNVRAMFile not found in VM info for %s", vmName)
+}
+
+// copyFile copies the file at src on top of the file at dst, overwriting it.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	return out.Sync()
+}
@@ -0,0 +1,51 @@
+package iso
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVMNVRAMPath(t *testing.T) {
+	cases := []struct {
+		name         string
+		stdout       string
+		err          error
+		expectedPath string
+		expectErr    bool
+	}{
+		{
+			name:         "NVRAM file found",
+			stdout:       "BIOSNVRAMFile=\"/vms/vm-name/vm-name.nvram\"\n",
+			expectedPath: "/vms/vm-name/vm-name.nvram",
+		},
+		{
+			name:      "NVRAM file missing from output",
+			stdout:    "memory=1024\n",
+			expectErr: true,
+		},
+		{
+			name:      "VBoxManage error",
+			err:       errors.New("VBoxManage failed"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &fakeOutputDriver{stdout: tc.stdout, err: tc.err}
+			path, err := vmNVRAMPath(driver, "vm-name")
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("vmNVRAMPath() expected an error, got path %q", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("vmNVRAMPath() unexpected error: %s", err)
+			}
+			if path != tc.expectedPath {
+				t.Fatalf("vmNVRAMPath() = %q, expected %q", path, tc.expectedPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package iso
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step converts the VM's hard disk to the format requested via
+// disk_output_format, once the VM itself has already been exported (or
+// export was skipped). It is a no-op when disk_output_format is unset; the
+// OVF/OVA bundle itself is controlled separately by the format option.
+//
+// Uses:
+//   config *config
+//   disk_path string
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   extra_artifact_files []string - The converted disk and its checksum
+//     sidecar are appended here, to be folded into the final artifact's
+//     file list.
+type stepConvertDisk struct{}
+
+// cloneMediumFormats maps a disk_output_format value to the --format
+// argument VBoxManage clonemedium expects.
+var cloneMediumFormats = map[string]string{
+	"vmdk":  "VMDK",
+	"raw":   "RAW",
+	"qcow2": "RAW",
+}
+
+func (s *stepConvertDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+
+	format := config.DiskOutputFormat
+	if format == "" {
+		return multistep.ActionContinue
+	}
+
+	diskPathRaw, ok := state.GetOk("disk_path")
+	if !ok {
+		// Nothing to convert; stepCreateDisk/stepRestoreSnapshot didn't
+		// produce a disk path for this run.
+		return multistep.ActionContinue
+	}
+	diskPath := diskPathRaw.(string)
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	cloneFormat := cloneMediumFormats[format]
+	clonedPath := strings.TrimSuffix(diskPath, filepath.Ext(diskPath)) + "." + strings.ToLower(cloneFormat)
+
+	ui.Say(fmt.Sprintf("Cloning hard drive to %s...", format))
+	if err := driver.VBoxManage("clonemedium", "disk", diskPath, clonedPath, "--format", cloneFormat); err != nil {
+		err := fmt.Errorf("Error cloning hard drive: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	outputPath := clonedPath
+	if format == "qcow2" {
+		qcow2Path := strings.TrimSuffix(clonedPath, filepath.Ext(clonedPath)) + ".qcow2"
+
+		ui.Say("Converting hard drive to qcow2...")
+		cmd := exec.Command("qemu-img", "convert", "-O", "qcow2", clonedPath, qcow2Path)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			err := fmt.Errorf("Error converting hard drive to qcow2: %s\n%s", err, output)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		// The intermediate RAW clone was only needed as qemu-img's input;
+		// don't leave it behind alongside the qcow2 artifact.
+		if err := os.Remove(clonedPath); err != nil {
+			ui.Error(fmt.Sprintf("Error removing intermediate disk clone: %s", err))
+		}
+
+		outputPath = qcow2Path
+	}
+
+	checksumPath, err := writeChecksumSidecar(outputPath)
+	if err != nil {
+		err := fmt.Errorf("Error writing checksum for %s: %s", outputPath, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("disk_path", outputPath)
+	appendArtifactFile(state, outputPath)
+	appendArtifactFile(state, checksumPath)
+
+	return multistep.ActionContinue
+}
+
+// writeChecksumSidecar writes a "<path>.sha256" file containing the sha256
+// checksum of path, in the same "<hex>  <filename>" format as sha256sum, so
+// downstream builders can verify the converted disk without an additional
+// post-processor.
+func writeChecksumSidecar(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	checksumPath := path + ".sha256"
+	contents := fmt.Sprintf("%x  %s\n", h.Sum(nil), filepath.Base(path))
+	if err := ioutil.WriteFile(checksumPath, []byte(contents), 0644); err != nil {
+		return "", err
+	}
+
+	return checksumPath, nil
+}
+
+func (s *stepConvertDisk) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,146 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step creates the virtual disk that will be used as the boot disk
+// on the virtual machine.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   disk_path string - The path to the disk that was created.
+type stepCreateDisk struct{}
+
+func (s *stepCreateDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if skipInstall(state) {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	path := filepath.Join(config.OutputDir, config.VMName+".vdi")
+
+	// The controller that the hard drive will be attached to. storagectl
+	// requires a --add type even though we already know it from
+	// HardDriveInterface below.
+	controllerName, addType := storageControllerInfo(config.HardDriveInterface)
+
+	sizeKB := int64(config.DiskSize) * 1024
+
+	commands := make([][]string, 0, 5)
+	commands = append(commands, []string{
+		"createhd",
+		"--filename", path,
+		"--size", strconv.FormatInt(sizeKB, 10),
+		"--format", "VDI",
+		"--variant", "Standard",
+	})
+
+	commands = append(commands, storagectlCommand(config, controllerName, addType, config.HardDriveInterface))
+
+	// The ISO is attached by stepAttachISO, but its controller may be of a
+	// different type than the hard drive's and so needs to be created here
+	// too, since VBoxManage only allows one controller of a given type to
+	// back onto a single storagectl --add call per name.
+	isoControllerName, isoAddType := storageControllerInfo(config.ISOInterface)
+	if isoControllerName != controllerName {
+		commands = append(commands, storagectlCommand(config, isoControllerName, isoAddType, config.ISOInterface))
+	}
+
+	commands = append(commands, []string{
+		"storageattach", config.VMName,
+		"--storagectl", controllerName,
+		"--port", "0",
+		"--device", "0",
+		"--type", "hdd",
+		"--medium", path,
+	})
+
+	if config.HardDriveNonrotational {
+		commands = append(commands, []string{
+			"storageattach", config.VMName,
+			"--storagectl", controllerName,
+			"--port", "0",
+			"--device", "0",
+			"--nonrotational", "on",
+		})
+	}
+
+	if config.HardDriveDiscard {
+		commands = append(commands, []string{
+			"storageattach", config.VMName,
+			"--storagectl", controllerName,
+			"--port", "0",
+			"--device", "0",
+			"--discard", "on",
+		})
+	}
+
+	ui.Say("Creating hard drive...")
+	for _, command := range commands {
+		if err := driver.VBoxManage(command...); err != nil {
+			err := fmt.Errorf("Error creating hard drive: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	state.Put("disk_path", path)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepCreateDisk) Cleanup(state multistep.StateBag) {}
+
+// storageControllerInfo returns the storage controller name VirtualBox
+// conventionally uses for a given hard_drive_interface or iso_interface
+// value, along with the --add argument that storagectl expects to create
+// it.
+func storageControllerInfo(iface string) (name string, addType string) {
+	switch iface {
+	case "sata":
+		return "SATA Controller", "sata"
+	case "scsi":
+		return "SCSI Controller", "scsi"
+	case "virtio-scsi":
+		return "VirtIO Controller", "virtio"
+	case "nvme":
+		return "NVMe Controller", "pcie"
+	default:
+		return "IDE Controller", "ide"
+	}
+}
+
+// storagectlCommand builds the storagectl --add command for a controller,
+// including the port count flag for interfaces that support it.
+func storagectlCommand(config *Config, controllerName string, addType string, iface string) []string {
+	command := []string{
+		"storagectl", config.VMName,
+		"--name", controllerName,
+		"--add", addType,
+	}
+
+	switch iface {
+	case "sata":
+		command = append(command, "--portcount", strconv.Itoa(config.SATAPortCount))
+	case "nvme":
+		command = append(command, "--portcount", strconv.Itoa(config.NVMePortCount))
+	}
+
+	return command
+}
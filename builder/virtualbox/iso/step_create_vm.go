@@ -0,0 +1,86 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step creates the actual virtual machine.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   vmName string - The name of the VM
+type stepCreateVM struct {
+	vmName string
+}
+
+func (s *stepCreateVM) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if skipInstall(state) {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	ui.Say("Creating virtual machine...")
+	commands := make([][]string, 0, 2)
+	commands = append(commands, []string{
+		"createvm", "--name", config.VMName,
+		"--ostype", config.GuestOSType, "--register",
+	})
+
+	if config.Firmware != "" && config.Firmware != "bios" {
+		commands = append(commands, []string{
+			"modifyvm", config.VMName,
+			"--firmware", config.Firmware,
+		})
+	}
+
+	for _, command := range commands {
+		if err := driver.VBoxManage(command...); err != nil {
+			err := fmt.Errorf("Error creating VM: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	// Set the final name in the state bag so Cleanup knows what to remove
+	s.vmName = config.VMName
+	state.Put("vmName", s.vmName)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepCreateVM) Cleanup(state multistep.StateBag) {
+	if s.vmName == "" {
+		return
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	if _, cancelled := state.GetOk(multistep.StateCancelled); cancelled {
+		ui.Say("Deleting VM because of cancellation...")
+	} else if _, halted := state.GetOk(multistep.StateHalted); halted {
+		ui.Say("Deleting VM because of error...")
+	} else if config.KeepRegistered || config.SnapshotName != "" {
+		return
+	} else {
+		ui.Say("Deregistering and deleting VM...")
+	}
+
+	if err := driver.Delete(s.vmName); err != nil {
+		ui.Error(fmt.Sprintf("Error deleting VM: %s", err))
+	}
+}
@@ -0,0 +1,63 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// VirtualBox's OVF/OVA export does not bundle the VM's NVRAM content --
+// it isn't part of the OVF disk-image spec -- so on an EFI build this step
+// copies the VM's NVRAM file into the output directory and folds it into
+// the artifact's file list once export has finished. Without this, a
+// Secure Boot build's pre-populated PK/KEK/db keys would be lost from the
+// exported artifact.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   extra_artifact_files []string - The exported NVRAM file is appended
+//     here, to be folded into the final artifact's file list.
+type stepExportNVRAM struct{}
+
+func (s *stepExportNVRAM) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+
+	if config.Firmware == "bios" || config.SkipExport {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	nvramPath, err := vmNVRAMPath(driver, config.VMName)
+	if err != nil {
+		err := fmt.Errorf("Error locating VM NVRAM file: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	exportedPath := filepath.Join(config.OutputDir, config.VMName+".nvram")
+
+	ui.Say("Exporting NVRAM...")
+	if err := copyFile(nvramPath, exportedPath); err != nil {
+		err := fmt.Errorf("Error exporting NVRAM file: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	appendArtifactFile(state, exportedPath)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepExportNVRAM) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,30 @@
+package iso
+
+import (
+	"context"
+
+	"github.com/hashicorp/packer/helper/multistep"
+)
+
+// This step wraps another step and skips it entirely when a snapshot was
+// restored in place of installing the OS, since there's no installer left
+// to drive a boot command into.
+type stepSkippableIfRestored struct {
+	Step multistep.Step
+}
+
+func (s *stepSkippableIfRestored) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if skipInstall(state) {
+		return multistep.ActionContinue
+	}
+
+	return s.Step.Run(ctx, state)
+}
+
+func (s *stepSkippableIfRestored) Cleanup(state multistep.StateBag) {
+	if skipInstall(state) {
+		return
+	}
+
+	s.Step.Cleanup(state)
+}
@@ -0,0 +1,40 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step deletes the pre-provision snapshot taken by stepTakeSnapshot
+// once the build has finished, unless discard_snapshot was left false, in
+// which case the snapshot is retained for future incremental builds.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+type stepFinishSnapshot struct{}
+
+func (s *stepFinishSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+
+	if config.SnapshotName == "" || !config.DiscardSnapshot {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	ui.Say(fmt.Sprintf("Discarding snapshot %q...", config.SnapshotName))
+	if err := driver.VBoxManage("snapshot", config.VMName, "delete", config.SnapshotName); err != nil {
+		ui.Error(fmt.Sprintf("Error discarding snapshot: %s", err))
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepFinishSnapshot) Cleanup(state multistep.StateBag) {}
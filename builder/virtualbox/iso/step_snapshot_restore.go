@@ -0,0 +1,133 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step looks for a snapshot named snapshot_name on a VM named
+// vm_name. If it's found, the VM install (stepCreateVM, stepCreateDisk,
+// stepAttachISO, and the boot command) is skipped entirely in favor of
+// restoring the snapshot, which cuts provisioner development iteration
+// from a full OS install down to seconds.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   skip_install bool - True if an existing snapshot was restored.
+type stepRestoreSnapshot struct{}
+
+func (s *stepRestoreSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+
+	if config.SnapshotName == "" {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	if !vmExists(driver, config.VMName) {
+		return multistep.ActionContinue
+	}
+
+	if !snapshotExists(driver, config.VMName, config.SnapshotName) {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Restoring existing snapshot %q, skipping OS install...", config.SnapshotName))
+	if err := driver.VBoxManage("snapshot", config.VMName, "restore", config.SnapshotName); err != nil {
+		err := fmt.Errorf("Error restoring snapshot: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("skip_install", true)
+
+	// stepCreateDisk won't run to set disk_path. Only bother recovering it
+	// when disk_output_format will actually consume it -- a lookup failure
+	// here (stale hard_drive_interface, renamed controller, multiple disks)
+	// shouldn't fail an otherwise-successful restore that doesn't need it.
+	if config.DiskOutputFormat != "" {
+		controllerName, _ := storageControllerInfo(config.HardDriveInterface)
+		diskPath, err := findAttachedDiskPath(driver, config.VMName, controllerName)
+		if err != nil {
+			err := fmt.Errorf("Error locating restored VM's disk: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Put("disk_path", diskPath)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepRestoreSnapshot) Cleanup(state multistep.StateBag) {}
+
+// vmExists reports whether a VM named vmName is already registered.
+func vmExists(driver vboxcommon.Driver, vmName string) bool {
+	return driver.VBoxManage("showvminfo", vmName, "--machinereadable") == nil
+}
+
+// snapshotExists reports whether vmName has a snapshot named snapshotName.
+func snapshotExists(driver vboxInfoDriver, vmName string, snapshotName string) bool {
+	stdout, err := driver.VBoxManageWithOutput("snapshot", vmName, "list", "--machinereadable")
+	if err != nil {
+		return false
+	}
+
+	needle := fmt.Sprintf(`SnapshotName="%s"`, snapshotName)
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(line) == needle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipInstall returns true if a prior step decided to restore a snapshot
+// instead of installing the OS from scratch.
+func skipInstall(state multistep.StateBag) bool {
+	skip, ok := state.GetOk("skip_install")
+	return ok && skip.(bool)
+}
+
+// vboxInfoDriver is the slice of vboxcommon.Driver that the
+// --machinereadable parsing helpers in this package need, so they can be
+// exercised with a small fake in tests instead of a full Driver.
+type vboxInfoDriver interface {
+	VBoxManageWithOutput(args ...string) (string, error)
+}
+
+// findAttachedDiskPath asks VirtualBox, via showvminfo --machinereadable,
+// for the medium attached to port 0 device 0 of the named controller.
+func findAttachedDiskPath(driver vboxInfoDriver, vmName string, controllerName string) (string, error) {
+	stdout, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return "", err
+	}
+
+	needlePrefix := fmt.Sprintf(`"%s-0-0"=`, controllerName)
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, needlePrefix) {
+			continue
+		}
+
+		path := strings.TrimPrefix(line, needlePrefix)
+		return strings.Trim(path, `"`), nil
+	}
+
+	return "", fmt.Errorf("no medium attached to %s-0-0 for %s", controllerName, vmName)
+}
@@ -0,0 +1,110 @@
+package iso
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeOutputDriver is a minimal vboxInfoDriver for exercising the
+// --machinereadable parsing helpers without a real VBoxManage binary.
+type fakeOutputDriver struct {
+	stdout string
+	err    error
+}
+
+func (f *fakeOutputDriver) VBoxManageWithOutput(args ...string) (string, error) {
+	return f.stdout, f.err
+}
+
+func TestSnapshotExists(t *testing.T) {
+	cases := []struct {
+		name         string
+		stdout       string
+		err          error
+		snapshotName string
+		expected     bool
+	}{
+		{
+			name:         "snapshot present",
+			stdout:       "SnapshotName=\"base\"\nSnapshotUUID=\"1234\"\n",
+			snapshotName: "base",
+			expected:     true,
+		},
+		{
+			name:         "snapshot absent",
+			stdout:       "SnapshotName=\"other\"\n",
+			snapshotName: "base",
+			expected:     false,
+		},
+		{
+			name:         "no snapshots",
+			stdout:       "",
+			snapshotName: "base",
+			expected:     false,
+		},
+		{
+			name:         "VBoxManage error",
+			err:          errors.New("VBoxManage failed"),
+			snapshotName: "base",
+			expected:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &fakeOutputDriver{stdout: tc.stdout, err: tc.err}
+			if got := snapshotExists(driver, "vm-name", tc.snapshotName); got != tc.expected {
+				t.Fatalf("snapshotExists() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFindAttachedDiskPath(t *testing.T) {
+	cases := []struct {
+		name           string
+		stdout         string
+		err            error
+		controllerName string
+		expectedPath   string
+		expectErr      bool
+	}{
+		{
+			name:           "disk found",
+			stdout:         "\"SATA-0-0\"=\"/vms/disk.vmdk\"\n",
+			controllerName: "SATA",
+			expectedPath:   "/vms/disk.vmdk",
+		},
+		{
+			name:           "different controller attached",
+			stdout:         "\"IDE-0-0\"=\"/vms/disk.vmdk\"\n",
+			controllerName: "SATA",
+			expectErr:      true,
+		},
+		{
+			name:           "VBoxManage error",
+			err:            errors.New("VBoxManage failed"),
+			controllerName: "SATA",
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &fakeOutputDriver{stdout: tc.stdout, err: tc.err}
+			path, err := findAttachedDiskPath(driver, "vm-name", tc.controllerName)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("findAttachedDiskPath() expected an error, got path %q", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findAttachedDiskPath() unexpected error: %s", err)
+			}
+			if path != tc.expectedPath {
+				t.Fatalf("findAttachedDiskPath() = %q, expected %q", path, tc.expectedPath)
+			}
+		})
+	}
+}
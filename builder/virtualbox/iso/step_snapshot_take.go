@@ -0,0 +1,43 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+
+	vboxcommon "github.com/hashicorp/packer/builder/virtualbox/common"
+	"github.com/hashicorp/packer/helper/multistep"
+	"github.com/hashicorp/packer/packer"
+)
+
+// This step takes a snapshot named snapshot_name as soon as the
+// communicator comes up, before any provisioners run, so that a later
+// build can restore straight to a freshly-installed, unprovisioned VM.
+//
+// Uses:
+//   config *config
+//   driver vboxcommon.Driver
+//   ui     packer.Ui
+type stepTakeSnapshot struct{}
+
+func (s *stepTakeSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+
+	if config.SnapshotName == "" || skipInstall(state) {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	ui.Say(fmt.Sprintf("Taking snapshot %q...", config.SnapshotName))
+	if err := driver.VBoxManage("snapshot", config.VMName, "take", config.SnapshotName); err != nil {
+		err := fmt.Errorf("Error taking snapshot: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepTakeSnapshot) Cleanup(state multistep.StateBag) {}